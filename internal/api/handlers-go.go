@@ -1,18 +1,29 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	cryptossh "golang.org/x/crypto/ssh"
+
+	"github.com/yourusername/ssh-file-transfer-api/internal/backend"
 	"github.com/yourusername/ssh-file-transfer-api/internal/models"
 	"github.com/yourusername/ssh-file-transfer-api/internal/ssh"
+	"github.com/yourusername/ssh-file-transfer-api/internal/transfer"
 )
 
-// TransferFile handles file transfer requests
+// TransferFile accepts a file transfer request and runs it in the
+// background, returning immediately with an ID for polling or streaming.
 func (s *Server) TransferFile(c *gin.Context) {
 	var req models.FileTransferRequest
-	
+
 	// Validate request payload
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIError{
@@ -28,7 +39,97 @@ func (s *Server) TransferFile(c *gin.Context) {
 		req.TargetPort = 22
 	}
 
-	// Create SSH client
+	if err := validateTarget(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request payload",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	id, err := s.transferManager.Start(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to queue file transfer",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"id":     id,
+		"status": string(transfer.StateQueued),
+	})
+}
+
+// validateTarget checks that req carries the fields its destination backend
+// actually needs: TargetHost and TargetFilePath for the SFTP path (whether
+// driven by those fields directly or by an sftp:// TargetURI), and just a
+// well-formed TargetURI for everything else (e.g. s3://bucket/key).
+func validateTarget(req models.FileTransferRequest) error {
+	scheme, parsed, err := backend.ResolveURI(req.TargetURI)
+	if err != nil {
+		return err
+	}
+
+	if scheme != "sftp" {
+		return nil
+	}
+
+	host, filePath := req.TargetHost, req.TargetFilePath
+	if parsed != nil {
+		if parsed.Hostname() != "" {
+			host = parsed.Hostname()
+		}
+		if parsed.Path != "" {
+			filePath = parsed.Path
+		}
+	}
+
+	if host == "" {
+		return fmt.Errorf("target_host is required")
+	}
+	if filePath == "" {
+		return fmt.Errorf("target_file_path is required")
+	}
+	return nil
+}
+
+// runTransfer dispatches req to the backend named by its TargetURI scheme
+// ("s3" for object storage, "sftp" or an empty TargetURI for the existing
+// SSH-backed transfer) and performs the actual copy. It is the
+// transfer.TransferFunc that the Server's transfer.Manager invokes for every
+// queued transfer.
+func (s *Server) runTransfer(ctx context.Context, req models.FileTransferRequest, onProgress func(written, total int64)) (*models.FileTransferResponse, error) {
+	scheme, parsed, err := backend.ResolveURI(req.TargetURI)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme == "s3" {
+		return s.runS3Transfer(ctx, req, onProgress)
+	}
+
+	// scheme == "sftp": an explicit sftp:// target_uri overrides
+	// TargetHost/TargetPort/TargetFilePath; an empty TargetURI leaves them
+	// as the caller set them, so this is a no-op for existing callers.
+	if parsed != nil {
+		host := parsed.Hostname()
+		if host != "" {
+			req.TargetHost = host
+		}
+		if parsed.Port() != "" {
+			if port, err := strconv.Atoi(parsed.Port()); err == nil {
+				req.TargetPort = port
+			}
+		}
+		if parsed.Path != "" {
+			req.TargetFilePath = parsed.Path
+		}
+	}
+
 	username := req.Username
 	if username == "" {
 		username = s.config.SSHUsername
@@ -44,52 +145,275 @@ func (s *Server) TransferFile(c *gin.Context) {
 		keyPath = s.config.SSHKeyPath
 	}
 
-	client, err := ssh.NewClient(username, password, keyPath)
+	passphrase := req.PrivateKeyPassphrase
+	if passphrase == "" {
+		passphrase = s.config.SSHKeyPassphrase
+	}
+
+	hostKeyMode := req.HostKeyMode
+	if hostKeyMode == "" {
+		hostKeyMode = s.config.SSHHostKeyMode
+	}
+
+	client, err := ssh.NewClient(ssh.ClientOptions{
+		Username:             username,
+		Password:             password,
+		KeyPath:              keyPath,
+		PrivateKeyPassphrase: passphrase,
+		HostKeyVerifier:      s.hostKeyVerifier,
+		HostKeyMode:          ssh.HostKeyMode(hostKeyMode),
+		Pool:                 s.sshPool,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH client: %v", err)
+	}
+
+	return client.TransferFile(ctx, req, onProgress)
+}
+
+// runS3Transfer uploads req.SourceFilePath to the bucket/key encoded in
+// req.TargetURI using the S3/MinIO backend. It mirrors the response shape
+// ssh.Client.TransferFile produces for a single-file transfer.
+func (s *Server) runS3Transfer(ctx context.Context, req models.FileTransferRequest, onProgress func(written, total int64)) (*models.FileTransferResponse, error) {
+	startTime := time.Now()
+
+	opts := backend.S3Options{
+		Endpoint:        s.config.S3Endpoint,
+		AccessKeyID:     s.config.S3AccessKeyID,
+		SecretAccessKey: s.config.S3SecretAccessKey,
+		UseSSL:          s.config.S3UseSSL,
+		Region:          s.config.S3Region,
+	}
+	if creds := req.S3Credentials; creds != nil {
+		if creds.Endpoint != "" {
+			opts.Endpoint = creds.Endpoint
+		}
+		if creds.AccessKeyID != "" {
+			opts.AccessKeyID = creds.AccessKeyID
+		}
+		if creds.SecretAccessKey != "" {
+			opts.SecretAccessKey = creds.SecretAccessKey
+		}
+		if creds.UseSSL != nil {
+			opts.UseSSL = *creds.UseSSL
+		}
+		if creds.Region != "" {
+			opts.Region = creds.Region
+		}
+	}
+
+	s3Backend, err := backend.NewS3Backend(opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIError{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to create SSH client",
+		return nil, fmt.Errorf("failed to create S3 client: %v", err)
+	}
+
+	srcFile, err := os.Open(req.SourceFilePath)
+	if err != nil {
+		return &models.FileTransferResponse{
+			Status:     "failed",
+			SourceFile: req.SourceFilePath,
+			TargetFile: req.TargetURI,
+			StartTime:  startTime,
+			EndTime:    time.Now(),
+			Duration:   time.Since(startTime).String(),
+			Error:      fmt.Sprintf("failed to open source file: %v", err),
+		}, err
+	}
+	defer srcFile.Close()
+
+	bytesWritten, err := s3Backend.Put(ctx, srcFile, req.TargetURI)
+	if onProgress != nil {
+		onProgress(bytesWritten, bytesWritten)
+	}
+	if err != nil {
+		return &models.FileTransferResponse{
+			Status:       "failed",
+			SourceFile:   req.SourceFilePath,
+			TargetFile:   req.TargetURI,
+			BytesWritten: bytesWritten,
+			StartTime:    startTime,
+			EndTime:      time.Now(),
+			Duration:     time.Since(startTime).String(),
+			Error:        fmt.Sprintf("failed to upload object: %v", err),
+		}, err
+	}
+
+	endTime := time.Now()
+	return &models.FileTransferResponse{
+		ID:           fmt.Sprintf("transfer-%d", endTime.UnixNano()),
+		Status:       "completed",
+		SourceFile:   req.SourceFilePath,
+		TargetFile:   req.TargetURI,
+		BytesWritten: bytesWritten,
+		StartTime:    startTime,
+		EndTime:      endTime,
+		Duration:     endTime.Sub(startTime).String(),
+	}, nil
+}
+
+// CancelTransfer stops a queued or running transfer.
+func (s *Server) CancelTransfer(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := s.transferManager.Cancel(id); err != nil {
+		c.JSON(http.StatusConflict, models.APIError{
+			Code:    http.StatusConflict,
+			Message: "Unable to cancel transfer",
 			Details: err.Error(),
 		})
 		return
 	}
 
-	// Execute file transfer
-	response, err := client.TransferFile(req)
-	if err != nil {
-		// If response already contains error details, use it
-		if response != nil {
-			c.JSON(http.StatusInternalServerError, response)
-			return
+	c.JSON(http.StatusAccepted, gin.H{"id": id, "status": "canceling"})
+}
+
+// StreamTransferEvents streams progress updates for a transfer as
+// Server-Sent Events until it reaches a terminal state. The current record
+// is replayed as the first event, so a transfer that already finished (or
+// even already started) before the caller connected still gets its state
+// instead of the stream hanging with nothing to show.
+func (s *Server) StreamTransferEvents(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := s.transferManager.Get(id); err != nil {
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    http.StatusNotFound,
+			Message: "Transfer not found",
+		})
+		return
+	}
+
+	current, events, unsubscribe := s.transferManager.Subscribe(id)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	sentCurrent := false
+	c.Stream(func(w io.Writer) bool {
+		if !sentCurrent {
+			sentCurrent = true
+			data, err := json.Marshal(current)
+			if err != nil {
+				return false
+			}
+			c.SSEvent("progress", string(data))
+			return !isTerminal(current.Status)
+		}
+
+		select {
+		case rec, ok := <-events:
+			if !ok {
+				return false
+			}
+			data, err := json.Marshal(rec)
+			if err != nil {
+				return false
+			}
+			c.SSEvent("progress", string(data))
+			return !isTerminal(rec.Status)
+		case <-c.Request.Context().Done():
+			return false
 		}
-		
+	})
+}
+
+func isTerminal(status transfer.State) bool {
+	switch status {
+	case transfer.StateCompleted, transfer.StateFailed, transfer.StateCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// TrustHost pins a host's public key in known_hosts ahead of time so that
+// batch transfers to it succeed on first contact under strict or tofu mode.
+func (s *Server) TrustHost(c *gin.Context) {
+	var req models.TrustHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request payload",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	key, _, _, _, err := cryptossh.ParseAuthorizedKey([]byte(req.PublicKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid public key",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	hostname := fmt.Sprintf("[%s]:%d", req.Host, req.Port)
+	if req.Port == 22 {
+		hostname = req.Host
+	}
+
+	if err := s.hostKeyVerifier.TrustHost(hostname, key); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to transfer file",
+			Message: "Failed to pin host key",
 			Details: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "trusted",
+		"host":        hostname,
+		"fingerprint": cryptossh.FingerprintSHA256(key),
+	})
 }
 
 // GetFileTransferStatus retrieves the status of a file transfer
 func (s *Server) GetFileTransferStatus(c *gin.Context) {
 	id := c.Param("id")
-	
-	// In a real implementation, you would look up the transfer status from a database
-	// This is a simplified example
+
+	rec, err := s.transferManager.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    http.StatusNotFound,
+			Message: "Transfer not found",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.FileTransferStatus{
-		ID:               id,
-		Status:           "completed",
-		PercentComplete:  100.0,
-		BytesTransferred: 1024,
-		StartTime:        time.Now().Add(-1 * time.Minute),
-		LastUpdated:      time.Now(),
+		ID:               rec.ID,
+		Status:           string(rec.Status),
+		PercentComplete:  percentComplete(rec),
+		BytesTransferred: rec.BytesTransferred,
+		StartTime:        rec.StartTime,
+		LastUpdated:      rec.LastUpdated,
+		Error:            rec.Error,
+		AuthMethod:       rec.AuthMethod,
+		Files:            rec.Files,
 	})
 }
 
+func percentComplete(rec *transfer.Record) float64 {
+	if rec.Status == transfer.StateCompleted {
+		return 100.0
+	}
+	if rec.TotalBytes <= 0 {
+		return 0
+	}
+	return 100.0 * float64(rec.BytesTransferred) / float64(rec.TotalBytes)
+}
+
+// PoolStats reports the SSH connection pool's current hit/miss/active/idle
+// counters.
+func (s *Server) PoolStats(c *gin.Context) {
+	c.JSON(http.StatusOK, s.sshPool.Stats())
+}
+
 // HealthCheck provides a basic health check endpoint
 func (s *Server) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
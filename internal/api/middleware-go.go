@@ -1,11 +1,15 @@
 package api
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/ssh-file-transfer-api/internal/models"
+	"github.com/yourusername/ssh-file-transfer-api/internal/ratelimit"
 )
 
 // Logger middleware logs all requests
@@ -37,38 +41,50 @@ func Logger() gin.HandlerFunc {
 	}
 }
 
-// RateLimiter middleware limits request rates
-func RateLimiter(requests int, duration time.Duration) gin.HandlerFunc {
-	// In a real implementation, you'd use a proper rate limiter
-	// This is a simplified example
-	type client struct {
-		count    int
-		lastSeen time.Time
-	}
-	
-	clients := make(map[string]*client)
-	
+// RateLimiter middleware enforces limiter's token budget per client IP. The
+// rule applied is the longest-prefix match of the request path against
+// routeRules (e.g. "/api/v1/transfers" limited more strictly than the rest
+// of the API), falling back to defaultRule when nothing matches. Every
+// response carries the standard X-RateLimit-Limit, X-RateLimit-Remaining,
+// and X-RateLimit-Reset headers; a rejected request also gets Retry-After.
+func RateLimiter(limiter ratelimit.Limiter, routeRules map[string]ratelimit.Rule, defaultRule ratelimit.Rule) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		
-		// Clean up old entries
-		now := time.Now()
-		for clientIP, clientData := range clients {
-			if now.Sub(clientData.lastSeen) > duration {
-				delete(clients, clientIP)
+		rule := defaultRule
+		ruleKey := "default"
+		longestMatch := -1
+		for prefix, r := range routeRules {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) && len(prefix) > longestMatch {
+				longestMatch = len(prefix)
+				rule = r
+				ruleKey = prefix
 			}
 		}
-		
-		// Check if client exists
-		if _, exists := clients[ip]; !exists {
-			clients[ip] = &client{count: 0, lastSeen: now}
+
+		// Fold the matched rule into the key so routes with different
+		// budgets (e.g. /health vs /api/v1/transfers) don't share the same
+		// bucket/counter for a given client.
+		key := c.ClientIP() + "|" + ruleKey
+		result, err := limiter.Allow(c.Request.Context(), key, rule)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIError{
+				Code:    http.StatusInternalServerError,
+				Message: "Rate limiter unavailable",
+				Details: err.Error(),
+			})
+			c.Abort()
+			return
 		}
-		
-		// Update last seen
-		clients[ip].lastSeen = now
-		
-		// Check if rate limit exceeded
-		if clients[ip].count >= requests {
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+		if !result.Allowed {
+			retryAfter := int(time.Until(result.ResetAt).Seconds())
+			if retryAfter < 0 {
+				retryAfter = 0
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
 			c.JSON(http.StatusTooManyRequests, models.APIError{
 				Code:    http.StatusTooManyRequests,
 				Message: "Rate limit exceeded",
@@ -76,10 +92,27 @@ func RateLimiter(requests int, duration time.Duration) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		
-		// Increment request count
-		clients[ip].count++
-		
+
+		c.Next()
+	}
+}
+
+// RequireAPIKey middleware restricts an endpoint to callers who send key as
+// the X-API-Key header, for admin endpoints (e.g. host-key trust) that
+// would otherwise let any caller who can reach the API make a
+// security-sensitive change with no credential at all.
+func RequireAPIKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-API-Key")
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+			c.JSON(http.StatusUnauthorized, models.APIError{
+				Code:    http.StatusUnauthorized,
+				Message: "Missing or invalid API key",
+			})
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
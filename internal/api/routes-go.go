@@ -1,38 +1,103 @@
 package api
 
 import (
-	"time"
+	"fmt"
+	"log"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/ssh-file-transfer-api/internal/config"
+	"github.com/yourusername/ssh-file-transfer-api/internal/ratelimit"
+	"github.com/yourusername/ssh-file-transfer-api/internal/ssh"
+	"github.com/yourusername/ssh-file-transfer-api/internal/ssh/pool"
+	"github.com/yourusername/ssh-file-transfer-api/internal/transfer"
 )
 
 // Server represents the API server
 type Server struct {
-	router *gin.Engine
-	config *config.Config
+	router          *gin.Engine
+	config          *config.Config
+	hostKeyVerifier *ssh.HostKeyVerifier
+	transferManager *transfer.Manager
+	sshPool         *pool.Pool
 }
 
 // NewServer creates a new API server
 func NewServer(cfg *config.Config) *Server {
 	router := gin.Default()
-	
+
+	hostKeyVerifier, err := ssh.NewHostKeyVerifier(cfg.SSHKnownHosts)
+	if err != nil {
+		log.Fatalf("Failed to initialize host key verifier: %v", err)
+	}
+
+	store, err := newTransferStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize transfer store: %v", err)
+	}
+
 	server := &Server{
-		router: router,
-		config: cfg,
+		router:          router,
+		config:          cfg,
+		hostKeyVerifier: hostKeyVerifier,
+		sshPool: pool.New(pool.Options{
+			MaxIdlePerKey:       cfg.SSHPoolMaxIdle,
+			MaxLifetime:         cfg.SSHPoolMaxLifetime,
+			HealthCheckInterval: cfg.SSHPoolHealthCheckInterval,
+		}),
 	}
-	
+	server.transferManager = transfer.NewManager(store, server.runTransfer)
+
+	limiter, err := newRateLimiter(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limiter: %v", err)
+	}
+
 	// Apply middlewares
 	router.Use(Logger())
 	router.Use(ErrorHandler())
-	router.Use(RateLimiter(cfg.RateLimitRequests, cfg.RateLimitDuration))
-	
+	router.Use(RateLimiter(
+		limiter,
+		map[string]ratelimit.Rule{
+			"/api/v1/transfers": {Requests: cfg.TransfersRateLimitRequests, Window: cfg.TransfersRateLimitDuration},
+		},
+		ratelimit.Rule{Requests: cfg.RateLimitRequests, Window: cfg.RateLimitDuration},
+	))
+
 	// Setup routes
 	server.setupRoutes()
-	
+
 	return server
 }
 
+// newTransferStore builds the transfer.Store selected by
+// cfg.TransferStoreBackend ("memory", the default, or "bolt").
+func newTransferStore(cfg *config.Config) (transfer.Store, error) {
+	switch cfg.TransferStoreBackend {
+	case "", "memory":
+		return transfer.NewMemoryStore(), nil
+	case "bolt":
+		return transfer.NewBoltStore(cfg.TransferStorePath)
+	default:
+		return nil, fmt.Errorf("unknown transfer store backend %q", cfg.TransferStoreBackend)
+	}
+}
+
+// newRateLimiter builds the ratelimit.Limiter selected by
+// cfg.RateLimitBackend ("memory", the default, or "redis").
+func newRateLimiter(cfg *config.Config) (ratelimit.Limiter, error) {
+	switch cfg.RateLimitBackend {
+	case "", "memory":
+		return ratelimit.NewMemoryLimiter(cfg.RateLimitMaxClients), nil
+	case "redis":
+		if cfg.RedisAddr == "" {
+			return nil, fmt.Errorf("REDIS_ADDR is required when RATE_LIMIT_BACKEND=redis")
+		}
+		return ratelimit.NewRedisLimiter(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.RateLimitBackend)
+	}
+}
+
 // setupRoutes configures all API routes
 func (s *Server) setupRoutes() {
 	// Health check
@@ -46,7 +111,22 @@ func (s *Server) setupRoutes() {
 		{
 			transfers.POST("", s.TransferFile)
 			transfers.GET("/:id", s.GetFileTransferStatus)
+			transfers.DELETE("/:id", s.CancelTransfer)
+			transfers.GET("/:id/events", s.StreamTransferEvents)
+		}
+
+		// Host key administration endpoints. Gated by an API key since
+		// pinning a host's key is security-sensitive: an unauthenticated
+		// caller could pin their own key ahead of the real one and defeat
+		// known_hosts verification entirely, strict mode included.
+		hosts := v1.Group("/hosts")
+		hosts.Use(RequireAPIKey(s.config.AdminAPIKey))
+		{
+			hosts.POST("/trust", s.TrustHost)
 		}
+
+		// Connection pool metrics
+		v1.GET("/pool/stats", s.PoolStats)
 	}
 }
 
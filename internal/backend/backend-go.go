@@ -0,0 +1,55 @@
+// Package backend defines a storage-agnostic interface for pushing files to
+// a remote destination, along with an S3/MinIO implementation. There is no
+// SFTP implementation of Backend here; sftp:// targets are handled by
+// internal/ssh instead. FileTransferRequest.TargetURI selects which path a
+// transfer takes; see ResolveURI.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Backend writes files to a remote destination identified by a URI.
+type Backend interface {
+	// Put copies source to targetURI, returning the number of bytes written.
+	Put(ctx context.Context, source io.Reader, targetURI string) (int64, error)
+	// Stat reports the size in bytes of the object at targetURI.
+	Stat(ctx context.Context, targetURI string) (int64, error)
+	// Mkdir ensures any intermediate directories (or prefixes) implied by
+	// targetURI exist. It is a no-op for backends with no directory concept.
+	Mkdir(ctx context.Context, targetURI string) error
+}
+
+// ErrUnsupportedScheme is returned by ResolveURI for a scheme no Backend
+// implementation handles.
+type ErrUnsupportedScheme struct {
+	Scheme string
+}
+
+func (e *ErrUnsupportedScheme) Error() string {
+	return fmt.Sprintf("unsupported target URI scheme %q", e.Scheme)
+}
+
+// ResolveURI parses targetURI and reports which scheme it names ("sftp" or
+// "s3"). An empty targetURI resolves to "sftp" so existing callers that only
+// set TargetHost/TargetFilePath keep working unchanged.
+func ResolveURI(targetURI string) (scheme string, parsed *url.URL, err error) {
+	if targetURI == "" {
+		return "sftp", nil, nil
+	}
+
+	u, err := url.Parse(targetURI)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid target_uri: %v", err)
+	}
+
+	switch u.Scheme {
+	case "sftp", "s3":
+		return u.Scheme, u, nil
+	default:
+		return "", nil, &ErrUnsupportedScheme{Scheme: u.Scheme}
+	}
+}
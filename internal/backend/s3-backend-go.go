@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options configures a new S3Backend. Endpoint, AccessKeyID, and
+// SecretAccessKey are required; UseSSL and Region fall back to sane
+// defaults when unset.
+type S3Options struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+	Region          string
+}
+
+// S3Backend implements Backend against an S3-compatible object store
+// (AWS S3, MinIO, and similar), addressing objects with s3://bucket/key
+// target URIs.
+type S3Backend struct {
+	client *minio.Client
+	region string
+}
+
+// NewS3Backend dials an S3-compatible endpoint with static credentials.
+func NewS3Backend(opts S3Options) (*S3Backend, error) {
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("S3 endpoint is required")
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3 access key ID and secret access key are required")
+	}
+
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKeyID, opts.SecretAccessKey, ""),
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create S3 client: %v", err)
+	}
+
+	return &S3Backend{client: client, region: opts.Region}, nil
+}
+
+// bucketAndKey splits an s3://bucket/key target URI into its parts.
+func bucketAndKey(targetURI string) (bucket, key string, err error) {
+	u, err := url.Parse(targetURI)
+	if err != nil || u.Scheme != "s3" {
+		return "", "", fmt.Errorf("invalid s3 target_uri %q", targetURI)
+	}
+	bucket = u.Host
+	key = strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("s3 target_uri %q must be of the form s3://bucket/key", targetURI)
+	}
+	return bucket, key, nil
+}
+
+// Put uploads source to the bucket/key encoded in targetURI, creating the
+// bucket first if it doesn't already exist.
+func (b *S3Backend) Put(ctx context.Context, source io.Reader, targetURI string) (int64, error) {
+	bucket, key, err := bucketAndKey(targetURI)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := b.ensureBucket(ctx, bucket); err != nil {
+		return 0, err
+	}
+
+	info, err := b.client.PutObject(ctx, bucket, key, source, -1, minio.PutObjectOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("unable to upload object: %v", err)
+	}
+	return info.Size, nil
+}
+
+// Stat reports the size of the object encoded in targetURI.
+func (b *S3Backend) Stat(ctx context.Context, targetURI string) (int64, error) {
+	bucket, key, err := bucketAndKey(targetURI)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := b.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("unable to stat object: %v", err)
+	}
+	return info.Size, nil
+}
+
+// Mkdir ensures the bucket encoded in targetURI exists; S3 has no directory
+// concept beyond that, so the key prefix itself requires no extra setup.
+func (b *S3Backend) Mkdir(ctx context.Context, targetURI string) error {
+	bucket, _, err := bucketAndKey(targetURI)
+	if err != nil {
+		return err
+	}
+	return b.ensureBucket(ctx, bucket)
+}
+
+func (b *S3Backend) ensureBucket(ctx context.Context, bucket string) error {
+	exists, err := b.client.BucketExists(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("unable to check bucket: %v", err)
+	}
+	if exists {
+		return nil
+	}
+
+	if err := b.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: b.region}); err != nil {
+		return fmt.Errorf("unable to create bucket: %v", err)
+	}
+	return nil
+}
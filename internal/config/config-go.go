@@ -16,26 +16,93 @@ type Config struct {
 	MaxRequestSize    int64
 	RateLimitRequests int
 	RateLimitDuration time.Duration
+	// RateLimitBackend selects how request budgets are tracked: "memory"
+	// (the default, an LRU-bounded per-IP token bucket) or "redis" for
+	// multi-instance deployments. See internal/ratelimit.
+	RateLimitBackend    string
+	RateLimitMaxClients int
+	RedisAddr           string
+	RedisPassword       string
+	RedisDB             int
+	// TransfersRateLimitRequests/Duration overrides RateLimitRequests/
+	// RateLimitDuration for the /api/v1/transfers routes, which are more
+	// expensive than a health check and so get a stricter budget.
+	TransfersRateLimitRequests int
+	TransfersRateLimitDuration time.Duration
 
 	// SSH related configuration
-	SSHKeyPath  string
-	SSHUsername string
-	SSHPassword string
+	SSHKeyPath       string
+	SSHUsername      string
+	SSHPassword      string
+	SSHKeyPassphrase string
+	SSHKnownHosts    string
+	SSHHostKeyMode   string
+
+	// Transfer registry configuration
+	TransferStoreBackend string
+	TransferStorePath    string
+
+	// Object-storage (S3/MinIO) configuration, used when a transfer's
+	// target_uri has an s3:// scheme. See internal/backend.S3Backend.
+	S3Endpoint        string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3UseSSL          bool
+	S3Region          string
+
+	// SSH connection pool configuration. See internal/ssh/pool.
+	SSHPoolMaxIdle             int
+	SSHPoolMaxLifetime         time.Duration
+	SSHPoolHealthCheckInterval time.Duration
+
+	// AdminAPIKey gates the host-key administration endpoints (currently
+	// POST /api/v1/hosts/trust): callers must send it as the X-API-Key
+	// header. Without this, anyone who can reach the API could pin their
+	// own key for a host before it's ever legitimately contacted, which
+	// defeats known_hosts verification entirely (strict mode included).
+	AdminAPIKey string
 }
 
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Set defaults
 	cfg := &Config{
-		ServerPort:        "8080",
-		ReadTimeout:       10 * time.Second,
-		WriteTimeout:      10 * time.Second,
-		MaxRequestSize:    50 * 1024 * 1024, // 50MB
-		RateLimitRequests: 100,
-		RateLimitDuration: time.Minute,
+		ServerPort:          "8080",
+		ReadTimeout:         10 * time.Second,
+		WriteTimeout:        10 * time.Second,
+		MaxRequestSize:      50 * 1024 * 1024, // 50MB
+		RateLimitRequests:   100,
+		RateLimitDuration:   time.Minute,
+		RateLimitBackend:    "memory",
+		RateLimitMaxClients: 10000,
+		RedisAddr:           os.Getenv("REDIS_ADDR"),
+		RedisPassword:       os.Getenv("REDIS_PASSWORD"),
+		RedisDB:             0,
+
+		TransfersRateLimitRequests: 20,
+		TransfersRateLimitDuration: time.Minute,
+
 		SSHKeyPath:        os.Getenv("SSH_KEY_PATH"),
 		SSHUsername:       os.Getenv("SSH_USERNAME"),
 		SSHPassword:       os.Getenv("SSH_PASSWORD"),
+		SSHKeyPassphrase:  os.Getenv("SSH_KEY_PASSPHRASE"),
+		SSHKnownHosts:     os.Getenv("SSH_KNOWN_HOSTS"),
+		SSHHostKeyMode:    "strict",
+
+		TransferStoreBackend: "memory",
+		TransferStorePath:    "transfers.db",
+
+		S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+		S3AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3UseSSL:          true,
+		S3Region:          os.Getenv("S3_REGION"),
+
+		SSHPoolMaxIdle:             2,
+		SSHPoolMaxLifetime:         30 * time.Minute,
+		SSHPoolHealthCheckInterval: 30 * time.Second,
+
+		AdminAPIKey: os.Getenv("ADMIN_API_KEY"),
 	}
 
 	// Override defaults with environment variables if provided
@@ -43,6 +110,40 @@ func Load() (*Config, error) {
 		cfg.ServerPort = port
 	}
 
+	if hostKeyMode := os.Getenv("SSH_HOST_KEY_MODE"); hostKeyMode != "" {
+		cfg.SSHHostKeyMode = hostKeyMode
+	}
+
+	if backend := os.Getenv("TRANSFER_STORE_BACKEND"); backend != "" {
+		cfg.TransferStoreBackend = backend
+	}
+
+	if storePath := os.Getenv("TRANSFER_STORE_PATH"); storePath != "" {
+		cfg.TransferStorePath = storePath
+	}
+
+	if useSSL := os.Getenv("S3_USE_SSL"); useSSL != "" {
+		cfg.S3UseSSL = useSSL != "false"
+	}
+
+	if maxIdle := os.Getenv("SSH_POOL_MAX_IDLE"); maxIdle != "" {
+		if n, err := strconv.Atoi(maxIdle); err == nil {
+			cfg.SSHPoolMaxIdle = n
+		}
+	}
+
+	if maxLifetime := os.Getenv("SSH_POOL_MAX_LIFETIME"); maxLifetime != "" {
+		if duration, err := strconv.Atoi(maxLifetime); err == nil {
+			cfg.SSHPoolMaxLifetime = time.Duration(duration) * time.Second
+		}
+	}
+
+	if healthCheckInterval := os.Getenv("SSH_POOL_HEALTH_CHECK_INTERVAL"); healthCheckInterval != "" {
+		if duration, err := strconv.Atoi(healthCheckInterval); err == nil {
+			cfg.SSHPoolHealthCheckInterval = time.Duration(duration) * time.Second
+		}
+	}
+
 	if readTimeout := os.Getenv("READ_TIMEOUT"); readTimeout != "" {
 		if duration, err := strconv.Atoi(readTimeout); err == nil {
 			cfg.ReadTimeout = time.Duration(duration) * time.Second
@@ -73,14 +174,45 @@ func Load() (*Config, error) {
 		}
 	}
 
-	// Validate required configurations
-	if cfg.SSHKeyPath == "" && cfg.SSHPassword == "" {
-		return nil, errors.New("either SSH_KEY_PATH or SSH_PASSWORD must be provided")
+	if backend := os.Getenv("RATE_LIMIT_BACKEND"); backend != "" {
+		cfg.RateLimitBackend = backend
+	}
+
+	if maxClients := os.Getenv("RATE_LIMIT_MAX_CLIENTS"); maxClients != "" {
+		if n, err := strconv.Atoi(maxClients); err == nil {
+			cfg.RateLimitMaxClients = n
+		}
+	}
+
+	if redisDB := os.Getenv("REDIS_DB"); redisDB != "" {
+		if n, err := strconv.Atoi(redisDB); err == nil {
+			cfg.RedisDB = n
+		}
+	}
+
+	if requests := os.Getenv("TRANSFERS_RATE_LIMIT_REQUESTS"); requests != "" {
+		if n, err := strconv.Atoi(requests); err == nil {
+			cfg.TransfersRateLimitRequests = n
+		}
+	}
+
+	if duration := os.Getenv("TRANSFERS_RATE_LIMIT_DURATION"); duration != "" {
+		if n, err := strconv.Atoi(duration); err == nil {
+			cfg.TransfersRateLimitDuration = time.Duration(n) * time.Second
+		}
 	}
 
+	// Validate required configurations. SSH_KEY_PATH/SSH_PASSWORD are no
+	// longer required here: ssh-agent (via SSH_AUTH_SOCK) is a valid
+	// first-class auth method on its own, checked at connect time by
+	// buildAuthMethods rather than at startup.
 	if cfg.SSHUsername == "" {
 		return nil, errors.New("SSH_USERNAME is required")
 	}
 
+	if cfg.AdminAPIKey == "" {
+		return nil, errors.New("ADMIN_API_KEY is required to secure the host-key administration endpoints")
+	}
+
 	return cfg, nil
 }
@@ -4,13 +4,89 @@ import "time"
 
 // FileTransferRequest defines the request payload for file transfer
 type FileTransferRequest struct {
-	TargetHost     string `json:"target_host" binding:"required"`
-	TargetPort     int    `json:"target_port" binding:"required"`
+	// TargetHost, TargetPort, and TargetFilePath are required for the
+	// default SFTP path, driven by TargetHost/TargetPort/TargetFilePath or
+	// an sftp:// TargetURI. They're unused (and so not required) when
+	// TargetURI names another scheme, e.g. s3://bucket/key; see
+	// validateTarget in internal/api.
+	TargetHost     string `json:"target_host,omitempty"`
+	TargetPort     int    `json:"target_port,omitempty"`
 	SourceFilePath string `json:"source_file_path" binding:"required"`
-	TargetFilePath string `json:"target_file_path" binding:"required"`
+	TargetFilePath string `json:"target_file_path,omitempty"`
 	Username       string `json:"username,omitempty"`
 	Password       string `json:"password,omitempty"`
 	PrivateKeyPath string `json:"private_key_path,omitempty"`
+	// PrivateKeyPassphrase decrypts PrivateKeyPath when it holds an
+	// encrypted PEM key.
+	PrivateKeyPassphrase string `json:"private_key_passphrase,omitempty"`
+
+	// HostKeyMode selects how the remote host's SSH key is verified:
+	// "strict" (default), "tofu", or "insecure". See internal/ssh.HostKeyMode.
+	HostKeyMode string `json:"host_key_mode,omitempty"`
+
+	// Chunked splits the source file into chunks uploaded in parallel over
+	// multiple SFTP sessions instead of a single io.Copy. ChunkSize (bytes)
+	// and Concurrency (worker count) default to 8 MiB and 4 when unset. With
+	// Resume set, chunks already present on the remote file are skipped.
+	Chunked     bool  `json:"chunked,omitempty"`
+	ChunkSize   int64 `json:"chunk_size,omitempty"`
+	Concurrency int   `json:"concurrency,omitempty"`
+	Resume      bool  `json:"resume,omitempty"`
+
+	// Recursive treats SourceFilePath as a directory and pushes its whole
+	// tree to TargetFilePath, preserving the relative layout. Include and
+	// Exclude are doublestar-style glob patterns matched against each file's
+	// path relative to SourceFilePath; a file must match an Include pattern
+	// (when any are given) and must not match any Exclude pattern. DryRun
+	// reports what would be transferred without writing anything.
+	Recursive bool     `json:"recursive,omitempty"`
+	Include   []string `json:"include,omitempty"`
+	Exclude   []string `json:"exclude,omitempty"`
+	DryRun    bool     `json:"dry_run,omitempty"`
+
+	// TargetURI selects the destination backend and overrides TargetHost/
+	// TargetFilePath when set: "sftp://host:22/path" dispatches to the SSH/
+	// SFTP backend and "s3://bucket/key" to the S3/MinIO backend. Omit it to
+	// keep the existing SFTP-only behavior driven by TargetHost/
+	// TargetFilePath.
+	TargetURI string `json:"target_uri,omitempty"`
+	// S3Credentials overrides the server's S3/MinIO credentials for this
+	// request; leave unset to use the server's configured credentials.
+	S3Credentials *S3Credentials `json:"s3_credentials,omitempty"`
+
+	// NoPool dials a fresh SSH connection for this transfer and closes it
+	// afterward instead of reusing one from the server's connection pool.
+	NoPool bool `json:"no_pool,omitempty"`
+}
+
+// S3Credentials authenticates against an S3-compatible object store.
+type S3Credentials struct {
+	Endpoint        string `json:"endpoint,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	UseSSL          *bool  `json:"use_ssl,omitempty"`
+	Region          string `json:"region,omitempty"`
+}
+
+// FileResult reports the outcome of transferring a single file within a
+// recursive (directory/glob) transfer.
+type FileResult struct {
+	SourceFile   string `json:"source_file"`
+	TargetFile   string `json:"target_file"`
+	Status       string `json:"status"`
+	BytesWritten int64  `json:"bytes_written,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// TrustHostRequest pins a known_hosts entry for a host:port ahead of time so
+// that a subsequent transfer in strict or tofu mode succeeds on first
+// contact.
+type TrustHostRequest struct {
+	Host string `json:"host" binding:"required"`
+	Port int    `json:"port" binding:"required"`
+	// PublicKey is a single known_hosts-format public key, e.g. the output
+	// of `ssh-keyscan`: "ssh-ed25519 AAAA...".
+	PublicKey string `json:"public_key" binding:"required"`
 }
 
 // FileTransferResponse defines the response payload for file transfer
@@ -25,6 +101,12 @@ type FileTransferResponse struct {
 	EndTime      time.Time `json:"end_time"`
 	Duration     string    `json:"duration"`
 	Error        string    `json:"error,omitempty"`
+	// AuthMethod records which authentication method the server accepted:
+	// "agent", "publickey", "password", or "keyboard-interactive".
+	AuthMethod string `json:"auth_method,omitempty"`
+	// Files holds the per-file outcome of a recursive (directory/glob)
+	// transfer; it is empty for a single-file transfer.
+	Files []FileResult `json:"files,omitempty"`
 }
 
 // FileTransferStatus represents a status update for a file transfer operation
@@ -36,6 +118,13 @@ type FileTransferStatus struct {
 	StartTime     time.Time `json:"start_time"`
 	LastUpdated   time.Time `json:"last_updated"`
 	Error         string    `json:"error,omitempty"`
+	// AuthMethod records which authentication method the server accepted:
+	// "agent", "publickey", "password", or "keyboard-interactive".
+	AuthMethod string `json:"auth_method,omitempty"`
+	// Files holds the per-file outcome of a recursive (directory/glob)
+	// transfer, including a dry_run one; it is empty for a single-file
+	// transfer.
+	Files []FileResult `json:"files,omitempty"`
 }
 
 // APIError represents an error response
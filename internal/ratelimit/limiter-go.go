@@ -0,0 +1,32 @@
+// Package ratelimit implements request limiting, either as an in-process
+// token bucket (bounded by an LRU so the key space can't grow without bound)
+// or as a fixed-window counter backed by Redis for multi-instance
+// deployments.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Rule configures the request budget for a set of routes: at most Requests
+// requests per Window.
+type Rule struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Result reports a rate limit decision along with the values callers should
+// surface as X-RateLimit-*/Retry-After headers.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// Limiter decides whether a request identified by key, under the given
+// rule, should be allowed.
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
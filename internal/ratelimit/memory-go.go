@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const defaultMaxEntries = 10000
+
+// MemoryLimiter is an in-process token-bucket limiter keyed by an arbitrary
+// string (typically client IP). Keys are evicted least-recently-used once
+// more than maxEntries distinct keys have been seen, so the map can't grow
+// unbounded under high cardinality the way a naive implementation would.
+type MemoryLimiter struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	rule    Rule
+	limiter *rate.Limiter
+}
+
+// NewMemoryLimiter creates a MemoryLimiter. maxEntries <= 0 uses a default
+// of 10000 distinct keys.
+func NewMemoryLimiter(maxEntries int) *MemoryLimiter {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	return &MemoryLimiter{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Allow consumes one token from key's bucket, creating it (or resizing it,
+// if rule has changed) on first use.
+func (m *MemoryLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.getOrCreate(key, rule)
+	now := time.Now()
+
+	reservation := entry.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return Result{Limit: rule.Requests, ResetAt: now.Add(rule.Window)}, nil
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.Cancel()
+		return Result{Limit: rule.Requests, ResetAt: now.Add(delay)}, nil
+	}
+
+	remaining := int(entry.limiter.TokensAt(now))
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{Allowed: true, Limit: rule.Requests, Remaining: remaining, ResetAt: now.Add(rule.Window)}, nil
+}
+
+func (m *MemoryLimiter) getOrCreate(key string, rule Rule) *memoryEntry {
+	if el, ok := m.entries[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		if entry.rule != rule {
+			entry.rule = rule
+			entry.limiter.SetLimit(ratePerSecond(rule))
+			entry.limiter.SetBurst(rule.Requests)
+		}
+		m.ll.MoveToFront(el)
+		return entry
+	}
+
+	entry := &memoryEntry{
+		key:     key,
+		rule:    rule,
+		limiter: rate.NewLimiter(ratePerSecond(rule), rule.Requests),
+	}
+	el := m.ll.PushFront(entry)
+	m.entries[key] = el
+
+	if m.ll.Len() > m.maxEntries {
+		oldest := m.ll.Back()
+		if oldest != nil {
+			m.ll.Remove(oldest)
+			delete(m.entries, oldest.Value.(*memoryEntry).key)
+		}
+	}
+
+	return entry
+}
+
+// ratePerSecond converts a "Requests per Window" budget into the
+// tokens-per-second rate.Limiter expects.
+func ratePerSecond(rule Rule) rate.Limit {
+	if rule.Window <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(float64(rule.Requests) / rule.Window.Seconds())
+}
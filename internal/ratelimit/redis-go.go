@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript atomically increments the request counter for key and
+// arms its expiry only on the first increment, giving each key a fixed
+// window that ends one window length after the first request in it, however
+// busy the key stays in the meantime. Running it
+// as a script keeps the increment-then-expire sequence atomic across
+// concurrent requests from multiple API instances.
+const slidingWindowScript = `
+local current = redis.call("INCR", KEYS[1])
+if tonumber(current) == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+local ttl = redis.call("PTTL", KEYS[1])
+return {current, ttl}
+`
+
+// RedisLimiter is a counting rate limiter backed by Redis INCR/EXPIRE,
+// shareable across multiple API instances behind a load balancer.
+type RedisLimiter struct {
+	client *redis.Client
+	script *redis.Script
+}
+
+// NewRedisLimiter connects to a Redis server at addr.
+func NewRedisLimiter(addr, password string, db int) *RedisLimiter {
+	return &RedisLimiter{
+		client: redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: password,
+			DB:       db,
+		}),
+		script: redis.NewScript(slidingWindowScript),
+	}
+}
+
+// Allow increments the counter for key and compares it against rule.
+func (r *RedisLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	res, err := r.script.Run(ctx, r.client, []string{"ratelimit:" + key}, rule.Window.Milliseconds()).Result()
+	if err != nil {
+		return Result{}, fmt.Errorf("redis rate limit check failed: %v", err)
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected redis rate limit response: %v", res)
+	}
+	count, _ := values[0].(int64)
+	ttlMs, _ := values[1].(int64)
+	if ttlMs < 0 {
+		ttlMs = rule.Window.Milliseconds()
+	}
+
+	remaining := rule.Requests - int(count)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return Result{
+		Allowed:   count <= int64(rule.Requests),
+		Limit:     rule.Requests,
+		Remaining: remaining,
+		ResetAt:   time.Now().Add(time.Duration(ttlMs) * time.Millisecond),
+	}, nil
+}
+
+// Close releases the underlying Redis connection pool.
+func (r *RedisLimiter) Close() error {
+	return r.client.Close()
+}
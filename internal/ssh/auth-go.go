@@ -0,0 +1,127 @@
+package ssh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AuthOptions configures how a Client authenticates to a remote host.
+type AuthOptions struct {
+	Password             string
+	KeyPath              string
+	PrivateKeyPassphrase string
+}
+
+// buildAuthMethods assembles a fallback chain of AuthMethods, tried by the
+// SSH library in the order returned: ssh-agent (via SSH_AUTH_SOCK), then a
+// private key (optionally passphrase-protected), then a plain password,
+// then keyboard-interactive using the password as the answer to every
+// prompt. The returned pointer is set to the name of whichever method the
+// server ultimately accepted, once Dial succeeds.
+//
+// closeAgent releases the ssh-agent connection backing the agent auth
+// method, if one was opened; it's a no-op otherwise. The agent signers it
+// returns only work while that connection is open, so callers must wait
+// until the handshake that consumes methods has finished (succeeded or
+// failed) before calling closeAgent.
+func buildAuthMethods(opts AuthOptions) (methods []ssh.AuthMethod, usedMethod *string, closeAgent func()) {
+	usedMethod = new(string)
+	closeAgent = func() {}
+
+	if signers, closer, err := agentSigners(); err == nil {
+		closeAgent = closer
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			*usedMethod = "agent"
+			return signers, nil
+		}))
+	}
+
+	if opts.KeyPath != "" {
+		if signer, err := loadPrivateKey(opts.KeyPath, opts.PrivateKeyPassphrase); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				*usedMethod = "publickey"
+				return []ssh.Signer{signer}, nil
+			}))
+		}
+	}
+
+	if opts.Password != "" {
+		methods = append(methods, ssh.PasswordCallback(func() (string, error) {
+			*usedMethod = "password"
+			return opts.Password, nil
+		}))
+
+		methods = append(methods, ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+			*usedMethod = "keyboard-interactive"
+			answers := make([]string, len(questions))
+			for i := range answers {
+				answers[i] = opts.Password
+			}
+			return answers, nil
+		}))
+	}
+
+	return methods, usedMethod, closeAgent
+}
+
+// authFingerprint derives a short identifier for a credential set, used as
+// part of a connection pool key so distinct credentials to the same host
+// never share a pooled connection. It deliberately doesn't reverse cleanly
+// to the original secret.
+func authFingerprint(opts AuthOptions) string {
+	h := sha256.Sum256([]byte(opts.KeyPath + "\x00" + opts.PrivateKeyPassphrase + "\x00" + opts.Password))
+	return hex.EncodeToString(h[:8])
+}
+
+// agentSigners connects to the running ssh-agent over SSH_AUTH_SOCK and
+// returns the keys it offers, along with a closer for the underlying
+// connection. The signers call back into that connection to sign on the
+// server's behalf, so the caller must not invoke closer until it's done
+// with the handshake that uses them.
+func agentSigners() ([]ssh.Signer, func(), error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, nil, fmt.Errorf("SSH_AUTH_SOCK is not set")
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to ssh-agent: %v", err)
+	}
+
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("unable to list keys from ssh-agent: %v", err)
+	}
+	return signers, func() { conn.Close() }, nil
+}
+
+// loadPrivateKey reads and parses a private key file, decrypting it with
+// passphrase if one is supplied.
+func loadPrivateKey(path, passphrase string) (ssh.Signer, error) {
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key: %v", err)
+	}
+
+	if passphrase != "" {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse private key: %v", err)
+		}
+		return signer, nil
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key: %v", err)
+	}
+	return signer, nil
+}
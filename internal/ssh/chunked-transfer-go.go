@@ -0,0 +1,233 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"github.com/yourusername/ssh-file-transfer-api/internal/models"
+)
+
+const (
+	defaultChunkSize   = 8 * 1024 * 1024
+	defaultConcurrency = 4
+)
+
+// chunkSpec describes one slice of the source file.
+type chunkSpec struct {
+	offset int64
+	size   int64
+	done   bool
+}
+
+// transferFileChunked splits srcFile into fixed-size chunks and uploads them
+// in parallel over multiple SFTP sessions multiplexed on the same SSH
+// connection, each writing to its own offset with WriteAt. When req.Resume
+// is set, chunks whose remote bytes already match the source are skipped so
+// an interrupted transfer can continue where it left off.
+func (c *Client) transferFileChunked(ctx context.Context, conn *ssh.Client, req models.FileTransferRequest, srcFile *os.File, totalBytes int64, onProgress func(written, total int64)) (int64, error) {
+	chunkSize := req.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	chunks := planChunks(totalBytes, chunkSize)
+
+	sftpClient, err := sftp.NewClient(conn)
+	if err != nil {
+		return 0, fmt.Errorf("unable to create SFTP client: %v", err)
+	}
+	defer sftpClient.Close()
+
+	if err := sftpClient.MkdirAll(filepath.Dir(req.TargetFilePath)); err != nil {
+		return 0, fmt.Errorf("unable to create target directory: %v", err)
+	}
+
+	if req.Resume {
+		markCompletedChunks(sftpClient, req.TargetFilePath, srcFile, chunks)
+	}
+
+	dstFile, err := sftpClient.OpenFile(req.TargetFilePath, os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		return 0, fmt.Errorf("unable to create target file: %v", err)
+	}
+	if err := dstFile.Truncate(totalBytes); err != nil {
+		dstFile.Close()
+		return 0, fmt.Errorf("unable to preallocate target file: %v", err)
+	}
+	dstFile.Close()
+
+	var written int64
+	var mu sync.Mutex
+	pending := make([]int, 0, len(chunks))
+	for i, ch := range chunks {
+		if ch.done {
+			written += ch.size
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	if onProgress != nil {
+		onProgress(written, totalBytes)
+	}
+	if len(pending) == 0 {
+		return totalBytes, nil
+	}
+
+	jobs := make(chan int)
+	errs := make(chan error, len(pending))
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Each worker gets its own SFTP session so chunk writes don't
+			// serialize on a single SFTP channel.
+			workerSFTP, err := sftp.NewClient(conn)
+			if err != nil {
+				errs <- fmt.Errorf("unable to open SFTP session: %v", err)
+				return
+			}
+			defer workerSFTP.Close()
+
+			workerFile, err := workerSFTP.OpenFile(req.TargetFilePath, os.O_WRONLY)
+			if err != nil {
+				errs <- fmt.Errorf("unable to open target file: %v", err)
+				return
+			}
+			defer workerFile.Close()
+
+			buf := make([]byte, chunkSize)
+			for idx := range jobs {
+				if err := ctx.Err(); err != nil {
+					errs <- err
+					continue
+				}
+
+				ch := chunks[idx]
+				data := buf[:ch.size]
+				if _, err := srcFile.ReadAt(data, ch.offset); err != nil && err != io.EOF {
+					errs <- fmt.Errorf("unable to read chunk %d: %v", idx, err)
+					continue
+				}
+				if _, err := workerFile.WriteAt(data, ch.offset); err != nil {
+					errs <- fmt.Errorf("unable to write chunk %d: %v", idx, err)
+					continue
+				}
+
+				mu.Lock()
+				written += ch.size
+				w := written
+				mu.Unlock()
+				if onProgress != nil {
+					onProgress(w, totalBytes)
+				}
+				errs <- nil
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, idx := range pending {
+			select {
+			case jobs <- idx:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(errs)
+	}()
+
+	var firstErr error
+	for err := range errs {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return written, firstErr
+	}
+	return totalBytes, nil
+}
+
+// planChunks splits a file of totalSize bytes into fixed-size chunks.
+func planChunks(totalSize, chunkSize int64) []chunkSpec {
+	if totalSize <= 0 {
+		return []chunkSpec{{offset: 0, size: 0}}
+	}
+
+	numChunks := (totalSize + chunkSize - 1) / chunkSize
+	chunks := make([]chunkSpec, numChunks)
+	for i := range chunks {
+		offset := int64(i) * chunkSize
+		size := chunkSize
+		if remaining := totalSize - offset; remaining < size {
+			size = remaining
+		}
+		chunks[i] = chunkSpec{offset: offset, size: size}
+	}
+	return chunks
+}
+
+// markCompletedChunks flags chunks whose bytes are already present on the
+// remote file, by comparing a CRC32 of the local and remote bytes at each
+// chunk's offset. Chunks are left unmarked (and so re-uploaded) whenever the
+// remote file is too short or any check fails.
+func markCompletedChunks(sftpClient *sftp.Client, path string, srcFile *os.File, chunks []chunkSpec) {
+	info, err := sftpClient.Stat(path)
+	if err != nil {
+		return
+	}
+	remoteSize := info.Size()
+
+	remoteFile, err := sftpClient.Open(path)
+	if err != nil {
+		return
+	}
+	defer remoteFile.Close()
+
+	buf := make([]byte, 32*1024)
+	for i, ch := range chunks {
+		if ch.offset+ch.size > remoteSize {
+			continue
+		}
+
+		localCRC, err := chunkCRC32(srcFile, ch.offset, ch.size, buf)
+		if err != nil {
+			continue
+		}
+		remoteCRC, err := chunkCRC32(remoteFile, ch.offset, ch.size, buf)
+		if err != nil {
+			continue
+		}
+		if localCRC == remoteCRC {
+			chunks[i].done = true
+		}
+	}
+}
+
+// chunkCRC32 computes the CRC32 of size bytes starting at offset in r.
+func chunkCRC32(r io.ReaderAt, offset, size int64, buf []byte) (uint32, error) {
+	h := crc32.NewIEEE()
+	if _, err := io.CopyBuffer(h, io.NewSectionReader(r, offset, size), buf); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
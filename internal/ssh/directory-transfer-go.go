@@ -0,0 +1,152 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"github.com/yourusername/ssh-file-transfer-api/internal/models"
+)
+
+// transferDirectory walks req.SourceFilePath and copies every file that
+// passes the include/exclude filters to the matching path under
+// req.TargetFilePath, preserving the relative layout as well as each file's
+// mode and mtime. With req.DryRun it reports what would be transferred
+// without writing anything.
+func (c *Client) transferDirectory(ctx context.Context, conn *ssh.Client, req models.FileTransferRequest, onProgress func(written, total int64)) ([]models.FileResult, error) {
+	var sftpClient *sftp.Client
+	if !req.DryRun {
+		client, err := sftp.NewClient(conn)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create SFTP client: %v", err)
+		}
+		defer client.Close()
+		sftpClient = client
+	}
+
+	var results []models.FileResult
+	var totalWritten int64
+
+	walkErr := filepath.WalkDir(req.SourceFilePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(req.SourceFilePath, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if !matchesFilters(relPath, req.Include, req.Exclude) {
+			return nil
+		}
+
+		targetPath := filepath.ToSlash(filepath.Join(req.TargetFilePath, relPath))
+		result := models.FileResult{SourceFile: path, TargetFile: targetPath}
+
+		if req.DryRun {
+			result.Status = "would_transfer"
+			results = append(results, result)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			results = append(results, result)
+			return nil
+		}
+
+		written, copyErr := copyFileToRemote(sftpClient, path, targetPath, info)
+		totalWritten += written
+		if onProgress != nil {
+			onProgress(totalWritten, 0)
+		}
+
+		if copyErr != nil {
+			result.Status = "failed"
+			result.Error = copyErr.Error()
+		} else {
+			result.Status = "completed"
+			result.BytesWritten = written
+		}
+		results = append(results, result)
+		return nil
+	})
+
+	return results, walkErr
+}
+
+// copyFileToRemote copies a single local file to targetPath over sftpClient,
+// creating intermediate directories and preserving mode/mtime.
+func copyFileToRemote(sftpClient *sftp.Client, srcPath, targetPath string, info os.FileInfo) (int64, error) {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open source file: %v", err)
+	}
+	defer srcFile.Close()
+
+	if err := sftpClient.MkdirAll(filepath.Dir(targetPath)); err != nil {
+		return 0, fmt.Errorf("unable to create target directory: %v", err)
+	}
+
+	dstFile, err := sftpClient.Create(targetPath)
+	if err != nil {
+		return 0, fmt.Errorf("unable to create target file: %v", err)
+	}
+	defer dstFile.Close()
+
+	written, err := dstFile.ReadFrom(srcFile)
+	if err != nil {
+		return written, fmt.Errorf("unable to copy file contents: %v", err)
+	}
+
+	if err := sftpClient.Chmod(targetPath, info.Mode()); err != nil {
+		return written, fmt.Errorf("unable to set file mode: %v", err)
+	}
+	if err := sftpClient.Chtimes(targetPath, info.ModTime(), info.ModTime()); err != nil {
+		return written, fmt.Errorf("unable to set file mtime: %v", err)
+	}
+
+	return written, nil
+}
+
+// matchesFilters reports whether relPath should be transferred: it must
+// match at least one include pattern (when any are given) and none of the
+// exclude patterns. Patterns use doublestar syntax, so "**/*.log" matches
+// at any depth.
+func matchesFilters(relPath string, include, exclude []string) bool {
+	if len(include) > 0 {
+		matched := false
+		for _, pattern := range include {
+			if ok, _ := doublestar.Match(pattern, relPath); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, pattern := range exclude {
+		if ok, _ := doublestar.Match(pattern, relPath); ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,180 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyMode selects how a remote host's SSH key is verified before a
+// connection is trusted.
+type HostKeyMode string
+
+const (
+	// HostKeyModeStrict rejects any host that is not already present in
+	// known_hosts. This is the default and the only mode safe for
+	// unattended production use.
+	HostKeyModeStrict HostKeyMode = "strict"
+	// HostKeyModeTOFU ("trust on first use") accepts and pins the key of
+	// any host seen for the first time, but fails on a later mismatch.
+	HostKeyModeTOFU HostKeyMode = "tofu"
+	// HostKeyModeInsecure skips verification entirely. Intended for local
+	// development/testing only.
+	HostKeyModeInsecure HostKeyMode = "insecure"
+)
+
+// HostKeyMismatchError is returned when a remote host presents a key that
+// conflicts with an existing known_hosts entry for that host.
+type HostKeyMismatchError struct {
+	Host        string
+	Fingerprint string
+}
+
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key mismatch for %s: remote offered %s, which does not match known_hosts", e.Host, e.Fingerprint)
+}
+
+// HostKeyVerifier loads a known_hosts file and produces ssh.HostKeyCallbacks
+// for it, optionally pinning new hosts on first contact (TOFU).
+type HostKeyVerifier struct {
+	path string
+}
+
+// NewHostKeyVerifier creates a verifier backed by the known_hosts file at
+// path. If path is empty, it falls back to the SSH_KNOWN_HOSTS environment
+// variable and then to ~/.ssh/known_hosts. The file (and its parent
+// directory) is created if it does not already exist.
+func NewHostKeyVerifier(path string) (*HostKeyVerifier, error) {
+	if path == "" {
+		path = os.Getenv("SSH_KNOWN_HOSTS")
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine home directory for known_hosts: %v", err)
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create known_hosts directory: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create known_hosts file: %v", err)
+	}
+	f.Close()
+
+	return &HostKeyVerifier{path: path}, nil
+}
+
+// Callback builds an ssh.HostKeyCallback enforcing the given mode.
+func (v *HostKeyVerifier) Callback(mode HostKeyMode) (ssh.HostKeyCallback, error) {
+	switch mode {
+	case HostKeyModeInsecure:
+		return ssh.InsecureIgnoreHostKey(), nil
+	case HostKeyModeTOFU:
+		return v.tofuCallback()
+	case HostKeyModeStrict, "":
+		return v.strictCallback()
+	default:
+		return nil, fmt.Errorf("unknown host key mode %q", mode)
+	}
+}
+
+func (v *HostKeyVerifier) strictCallback() (ssh.HostKeyCallback, error) {
+	cb, err := knownhosts.New(v.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load known_hosts from %s: %v", v.path, err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := cb(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) > 0 {
+			return &HostKeyMismatchError{Host: hostname, Fingerprint: ssh.FingerprintSHA256(key)}
+		}
+		return err
+	}, nil
+}
+
+func (v *HostKeyVerifier) tofuCallback() (ssh.HostKeyCallback, error) {
+	known, err := knownhosts.New(v.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load known_hosts from %s: %v", v.path, err)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := known(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			// The host is already known under a different key: never
+			// silently re-trust, even in TOFU mode.
+			return &HostKeyMismatchError{Host: hostname, Fingerprint: ssh.FingerprintSHA256(key)}
+		}
+
+		// First contact: trust and persist the key for next time.
+		if err := v.appendKnownHost(hostname, key); err != nil {
+			return fmt.Errorf("unable to persist trusted host key for %s: %v", hostname, err)
+		}
+		return nil
+	}, nil
+}
+
+// TrustHost pins hostname's key ahead of time, e.g. from an admin endpoint,
+// so that a later strict or TOFU connection succeeds on first contact.
+func (v *HostKeyVerifier) TrustHost(hostname string, key ssh.PublicKey) error {
+	return v.appendKnownHost(hostname, key)
+}
+
+func (v *HostKeyVerifier) appendKnownHost(hostname string, key ssh.PublicKey) error {
+	unlock, err := lockFile(v.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(v.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(knownhosts.Line([]string{hostname}, key) + "\n"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// lockFile takes a simple cross-process advisory lock by exclusively
+// creating path, spinning briefly if another process already holds it.
+// It returns a function that releases the lock.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("unable to acquire lock %s: %v", path, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s", path)
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
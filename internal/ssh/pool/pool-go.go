@@ -0,0 +1,267 @@
+// Package pool caches authenticated SSH connections so repeated transfers to
+// the same host don't each pay for a fresh TCP handshake, key exchange, and
+// authentication round trip.
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Options configures a Pool.
+type Options struct {
+	// MaxIdlePerKey caps how many idle connections are kept per key; extra
+	// connections are closed on release instead of pooled. Defaults to 2.
+	MaxIdlePerKey int
+	// MaxLifetime is how long a connection may stay in the pool, counted
+	// from the moment it was last released, before it's closed instead of
+	// reused. A connection that is taken and released again starts a new
+	// period; its original dial time is not tracked. Defaults to 30 minutes.
+	MaxLifetime time.Duration
+	// HealthCheckInterval is how often idle connections are pinged with a
+	// keepalive@openssh.com global request; connections that fail the check
+	// are closed and evicted. Defaults to 30 seconds.
+	HealthCheckInterval time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxIdlePerKey <= 0 {
+		o.MaxIdlePerKey = 2
+	}
+	if o.MaxLifetime <= 0 {
+		o.MaxLifetime = 30 * time.Minute
+	}
+	if o.HealthCheckInterval <= 0 {
+		o.HealthCheckInterval = 30 * time.Second
+	}
+	return o
+}
+
+// Stats reports point-in-time pool counters.
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Active int64 `json:"active"`
+	Idle   int64 `json:"idle"`
+}
+
+// pooledConn is one idle connection waiting to be reused.
+type pooledConn struct {
+	client    *ssh.Client
+	createdAt time.Time
+}
+
+// Pool caches *ssh.Client connections keyed by destination and credentials.
+type Pool struct {
+	opts Options
+
+	mu     sync.Mutex
+	idle   map[string][]*pooledConn
+	active map[string]int64
+
+	hits   int64
+	misses int64
+
+	closeOnce sync.Once
+	stopCh    chan struct{}
+}
+
+// New creates a Pool and starts its background health-check sweep.
+func New(opts Options) *Pool {
+	p := &Pool{
+		opts:   opts.withDefaults(),
+		idle:   make(map[string][]*pooledConn),
+		active: make(map[string]int64),
+		stopCh: make(chan struct{}),
+	}
+	go p.healthCheckLoop()
+	return p
+}
+
+// Key builds the cache key for a destination and credential set, as
+// "host:port|user|auth-fingerprint".
+func Key(host string, port int, user, authFingerprint string) string {
+	return fmt.Sprintf("%s:%d|%s|%s", host, port, user, authFingerprint)
+}
+
+// Get returns a cached connection for key if one is idle and still within
+// its lifetime, otherwise it calls dial to create a new one. The caller must
+// call Release (or Discard, if the connection turned out to be broken) when
+// done with it.
+func (p *Pool) Get(key string, dial func() (*ssh.Client, error)) (client *ssh.Client, fromPool bool, err error) {
+	p.mu.Lock()
+	for len(p.idle[key]) > 0 {
+		n := len(p.idle[key])
+		conn := p.idle[key][n-1]
+		p.idle[key] = p.idle[key][:n-1]
+
+		if time.Since(conn.createdAt) >= p.opts.MaxLifetime {
+			p.mu.Unlock()
+			conn.client.Close()
+			p.mu.Lock()
+			continue
+		}
+
+		atomic.AddInt64(&p.hits, 1)
+		p.active[key]++
+		p.mu.Unlock()
+		return conn.client, true, nil
+	}
+	p.mu.Unlock()
+
+	atomic.AddInt64(&p.misses, 1)
+	client, err = dial()
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.mu.Lock()
+	p.active[key]++
+	p.mu.Unlock()
+	return client, false, nil
+}
+
+// Release returns client to the idle pool for key, or closes it if the pool
+// for that key is already at MaxIdlePerKey.
+func (p *Pool) Release(key string, client *ssh.Client) {
+	p.mu.Lock()
+	p.active[key]--
+	if len(p.idle[key]) >= p.opts.MaxIdlePerKey {
+		p.mu.Unlock()
+		client.Close()
+		return
+	}
+	p.idle[key] = append(p.idle[key], &pooledConn{client: client, createdAt: time.Now()})
+	p.mu.Unlock()
+}
+
+// Discard closes client and removes it from the active count for key
+// without returning it to the idle pool. Call this instead of Release when
+// the caller knows the connection is broken.
+func (p *Pool) Discard(key string, client *ssh.Client) {
+	p.mu.Lock()
+	p.active[key]--
+	p.mu.Unlock()
+	client.Close()
+}
+
+// Stats reports current pool counters.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var active, idle int64
+	for _, n := range p.active {
+		active += n
+	}
+	for _, conns := range p.idle {
+		idle += int64(len(conns))
+	}
+
+	return Stats{
+		Hits:   atomic.LoadInt64(&p.hits),
+		Misses: atomic.LoadInt64(&p.misses),
+		Active: active,
+		Idle:   idle,
+	}
+}
+
+// Close stops the health-check loop and closes every idle connection.
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stopCh)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for key, conns := range p.idle {
+			for _, conn := range conns {
+				conn.client.Close()
+			}
+			delete(p.idle, key)
+		}
+	})
+}
+
+// healthCheckLoop periodically pings idle connections with a
+// keepalive@openssh.com global request and evicts any that fail to respond
+// or have outlived MaxLifetime.
+func (p *Pool) healthCheckLoop() {
+	ticker := time.NewTicker(p.opts.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.sweep()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+func (p *Pool) sweep() {
+	p.mu.Lock()
+	var expired []*pooledConn
+	for key, conns := range p.idle {
+		var kept []*pooledConn
+		for _, conn := range conns {
+			if time.Since(conn.createdAt) >= p.opts.MaxLifetime {
+				expired = append(expired, conn)
+				continue
+			}
+			kept = append(kept, conn)
+		}
+		p.idle[key] = kept
+	}
+	p.mu.Unlock()
+
+	for _, conn := range expired {
+		conn.client.Close()
+	}
+
+	// Ping whatever is left idle; anything that fails to respond is broken
+	// and gets evicted too.
+	p.mu.Lock()
+	remaining := make(map[string][]*pooledConn, len(p.idle))
+	for key, conns := range p.idle {
+		remaining[key] = append([]*pooledConn(nil), conns...)
+	}
+	p.mu.Unlock()
+
+	var dead []*pooledConn
+	for _, conns := range remaining {
+		for _, conn := range conns {
+			if _, _, err := conn.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				dead = append(dead, conn)
+			}
+		}
+	}
+	if len(dead) == 0 {
+		return
+	}
+
+	deadSet := make(map[*pooledConn]bool, len(dead))
+	for _, conn := range dead {
+		deadSet[conn] = true
+	}
+
+	p.mu.Lock()
+	for key, conns := range p.idle {
+		var kept []*pooledConn
+		for _, conn := range conns {
+			if !deadSet[conn] {
+				kept = append(kept, conn)
+			}
+		}
+		p.idle[key] = kept
+	}
+	p.mu.Unlock()
+
+	for _, conn := range dead {
+		conn.client.Close()
+	}
+}
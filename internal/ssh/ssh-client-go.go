@@ -1,94 +1,166 @@
 package ssh
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	"github.com/yourusername/ssh-file-transfer-api/internal/models"
+	"github.com/yourusername/ssh-file-transfer-api/internal/ssh/pool"
 )
 
 // Client manages SSH connections and file transfers
 type Client struct {
-	config *ssh.ClientConfig
+	config          *ssh.ClientConfig
+	hostKeyVerifier *HostKeyVerifier
+	hostKeyMode     HostKeyMode
+	usedAuthMethod  *string
+	authFingerprint string
+	// closeAgent releases the ssh-agent connection backing config's agent
+	// auth method, if any. It's safe to call once this client is done
+	// dialing (successfully or not); see buildAuthMethods.
+	closeAgent func()
+
+	// pool, when set, caches and reuses the SSH connections TransferFile
+	// dials instead of tearing one down after every transfer. See
+	// internal/ssh/pool.
+	pool *pool.Pool
+}
+
+// ClientOptions configures a new Client.
+type ClientOptions struct {
+	Username             string
+	Password             string
+	KeyPath              string
+	PrivateKeyPassphrase string
+
+	// HostKeyVerifier supplies the known_hosts-backed callback used to
+	// verify remote host keys. Required.
+	HostKeyVerifier *HostKeyVerifier
+	// HostKeyMode selects strict/tofu/insecure verification. Defaults to
+	// HostKeyModeStrict.
+	HostKeyMode HostKeyMode
+
+	// Pool, when set, is consulted for an existing connection to reuse
+	// before TransferFile dials a new one; see models.FileTransferRequest's
+	// NoPool flag for opting a single request out.
+	Pool *pool.Pool
 }
 
 // NewClient creates a new SSH client
-func NewClient(username, password, keyPath string) (*Client, error) {
-	config := &ssh.ClientConfig{
-		User:            username,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // In production, use ssh.FixedHostKey or ssh.KnownHosts
-		Timeout:         30 * time.Second,
+func NewClient(opts ClientOptions) (*Client, error) {
+	if opts.HostKeyVerifier == nil {
+		return nil, fmt.Errorf("host key verifier is required")
 	}
 
-	// Use password if provided
-	if password != "" {
-		config.Auth = []ssh.AuthMethod{
-			ssh.Password(password),
-		}
-	} else if keyPath != "" {
-		// Otherwise use private key
-		key, err := os.ReadFile(keyPath)
-		if err != nil {
-			return nil, fmt.Errorf("unable to read private key: %v", err)
-		}
+	hostKeyCallback, err := opts.HostKeyVerifier.Callback(opts.HostKeyMode)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build host key callback: %v", err)
+	}
 
-		signer, err := ssh.ParsePrivateKey(key)
-		if err != nil {
-			return nil, fmt.Errorf("unable to parse private key: %v", err)
-		}
+	authOpts := AuthOptions{
+		Password:             opts.Password,
+		KeyPath:              opts.KeyPath,
+		PrivateKeyPassphrase: opts.PrivateKeyPassphrase,
+	}
+	authMethods, usedAuthMethod, closeAgent := buildAuthMethods(authOpts)
+	if len(authMethods) == 0 {
+		closeAgent()
+		return nil, fmt.Errorf("no authentication method available: provide a password, a private key, or run an ssh-agent")
+	}
 
-		config.Auth = []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
-		}
-	} else {
-		return nil, fmt.Errorf("either password or keyPath must be provided")
+	config := &ssh.ClientConfig{
+		User:            opts.Username,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
 	}
 
-	return &Client{config: config}, nil
+	return &Client{
+		config:          config,
+		hostKeyVerifier: opts.HostKeyVerifier,
+		hostKeyMode:     opts.HostKeyMode,
+		usedAuthMethod:  usedAuthMethod,
+		authFingerprint: authFingerprint(authOpts),
+		closeAgent:      closeAgent,
+		pool:            opts.Pool,
+	}, nil
 }
 
-// TransferFile transfers a file to a remote server
-func (c *Client) TransferFile(req models.FileTransferRequest) (*models.FileTransferResponse, error) {
+// TransferFile transfers a file to a remote server. ctx governs both the
+// initial dial and the copy itself, so canceling it aborts an in-progress
+// transfer. If onProgress is non-nil, it is called periodically with the
+// bytes written so far and the total size of the source file (0 for a
+// recursive transfer, whose total is not known in advance).
+func (c *Client) TransferFile(ctx context.Context, req models.FileTransferRequest, onProgress func(written, total int64)) (*models.FileTransferResponse, error) {
 	startTime := time.Now()
 	
 	// Override client config with request-specific authentication if provided
 	config := c.config
+	usedAuthMethod := c.usedAuthMethod
+	fingerprint := c.authFingerprint
+	closeAgent := c.closeAgent
 	if req.Username != "" || req.Password != "" || req.PrivateKeyPath != "" {
-		config = &ssh.ClientConfig{
-			User:            req.Username,
-			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-			Timeout:         30 * time.Second,
+		hostKeyMode := c.hostKeyMode
+		if req.HostKeyMode != "" {
+			hostKeyMode = HostKeyMode(req.HostKeyMode)
+		}
+		hostKeyCallback, err := c.hostKeyVerifier.Callback(hostKeyMode)
+		if err != nil {
+			return nil, fmt.Errorf("unable to build host key callback: %v", err)
 		}
-		
-		if req.Password != "" {
-			config.Auth = []ssh.AuthMethod{
-				ssh.Password(req.Password),
-			}
-		} else if req.PrivateKeyPath != "" {
-			key, err := os.ReadFile(req.PrivateKeyPath)
-			if err != nil {
-				return nil, fmt.Errorf("unable to read private key: %v", err)
-			}
 
-			signer, err := ssh.ParsePrivateKey(key)
-			if err != nil {
-				return nil, fmt.Errorf("unable to parse private key: %v", err)
-			}
+		authOpts := AuthOptions{
+			Password:             req.Password,
+			KeyPath:              req.PrivateKeyPath,
+			PrivateKeyPassphrase: req.PrivateKeyPassphrase,
+		}
+		authMethods, used, overrideCloseAgent := buildAuthMethods(authOpts)
+		if len(authMethods) == 0 {
+			overrideCloseAgent()
+			return nil, fmt.Errorf("no authentication method available: provide a password, a private key, or run an ssh-agent")
+		}
+		usedAuthMethod = used
+		fingerprint = authFingerprint(authOpts)
 
-			config.Auth = []ssh.AuthMethod{
-				ssh.PublicKeys(signer),
-			}
+		// c's own agent connection, if any, won't be used for this
+		// request's overridden auth, so release it now rather than at the
+		// end of the client's lifetime.
+		closeAgent()
+		closeAgent = overrideCloseAgent
+
+		config = &ssh.ClientConfig{
+			User:            req.Username,
+			Auth:            authMethods,
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         30 * time.Second,
 		}
 	}
 
-	// Connect to SSH server
+	// Connect to SSH server, reusing a pooled connection when available.
 	addr := fmt.Sprintf("%s:%d", req.TargetHost, req.TargetPort)
-	conn, err := ssh.Dial("tcp", addr, config)
+	usePool := c.pool != nil && !req.NoPool
+	var poolKey string
+	var conn *ssh.Client
+	var err error
+	if usePool {
+		poolKey = pool.Key(req.TargetHost, req.TargetPort, config.User, fingerprint)
+		conn, _, err = c.pool.Get(poolKey, func() (*ssh.Client, error) {
+			return dialContext(ctx, addr, config)
+		})
+	} else {
+		conn, err = dialContext(ctx, addr, config)
+	}
+	// Signing against an agent (if config used one) only happens inside the
+	// dial/handshake above, so the agent connection can be released now.
+	closeAgent()
 	if err != nil {
 		return &models.FileTransferResponse{
 			Status:    "failed",
@@ -101,23 +173,56 @@ func (c *Client) TransferFile(req models.FileTransferRequest) (*models.FileTrans
 			Error:     fmt.Sprintf("failed to connect to SSH server: %v", err),
 		}, err
 	}
-	defer conn.Close()
 
-	// Create SFTP client
-	sftpClient, err := sftp.NewClient(conn)
-	if err != nil {
+	resp, transferErr := c.doTransfer(ctx, conn, req, usedAuthMethod, startTime, onProgress)
+
+	if usePool {
+		if transferErr != nil {
+			c.pool.Discard(poolKey, conn)
+		} else {
+			c.pool.Release(poolKey, conn)
+		}
+	} else {
+		conn.Close()
+	}
+
+	return resp, transferErr
+}
+
+// doTransfer performs the copy itself once a connection is in hand,
+// dispatching to the recursive, chunked, or single-stream path and building
+// the response common to all three.
+func (c *Client) doTransfer(ctx context.Context, conn *ssh.Client, req models.FileTransferRequest, usedAuthMethod *string, startTime time.Time, onProgress func(written, total int64)) (*models.FileTransferResponse, error) {
+	if req.Recursive {
+		results, err := c.transferDirectory(ctx, conn, req, onProgress)
+		endTime := time.Now()
+		var bytesWritten int64
+		for _, r := range results {
+			bytesWritten += r.BytesWritten
+		}
+
+		status := "completed"
+		errMsg := ""
+		if err != nil {
+			status = "failed"
+			errMsg = fmt.Sprintf("failed to transfer directory: %v", err)
+		}
+
 		return &models.FileTransferResponse{
-			Status:    "failed",
-			SourceFile: req.SourceFilePath,
-			TargetFile: req.TargetFilePath,
-			TargetHost: req.TargetHost,
-			StartTime: startTime,
-			EndTime:   time.Now(),
-			Duration:  time.Since(startTime).String(),
-			Error:     fmt.Sprintf("failed to create SFTP client: %v", err),
+			ID:           fmt.Sprintf("transfer-%d", time.Now().UnixNano()),
+			Status:       status,
+			SourceFile:   req.SourceFilePath,
+			TargetFile:   req.TargetFilePath,
+			TargetHost:   req.TargetHost,
+			BytesWritten: bytesWritten,
+			StartTime:    startTime,
+			EndTime:      endTime,
+			Duration:     endTime.Sub(startTime).String(),
+			Error:        errMsg,
+			AuthMethod:   *usedAuthMethod,
+			Files:        results,
 		}, err
 	}
-	defer sftpClient.Close()
 
 	// Open local file
 	srcFile, err := os.Open(req.SourceFilePath)
@@ -135,50 +240,28 @@ func (c *Client) TransferFile(req models.FileTransferRequest) (*models.FileTrans
 	}
 	defer srcFile.Close()
 
-	// Create target directory if it doesn't exist
-	targetDir := filepath.Dir(req.TargetFilePath)
-	if err := sftpClient.MkdirAll(targetDir); err != nil {
-		return &models.FileTransferResponse{
-			Status:    "failed",
-			SourceFile: req.SourceFilePath,
-			TargetFile: req.TargetFilePath,
-			TargetHost: req.TargetHost,
-			StartTime: startTime,
-			EndTime:   time.Now(),
-			Duration:  time.Since(startTime).String(),
-			Error:     fmt.Sprintf("failed to create target directory: %v", err),
-		}, err
+	totalBytes := int64(0)
+	if info, statErr := srcFile.Stat(); statErr == nil {
+		totalBytes = info.Size()
 	}
 
-	// Create remote file
-	dstFile, err := sftpClient.Create(req.TargetFilePath)
-	if err != nil {
-		return &models.FileTransferResponse{
-			Status:    "failed",
-			SourceFile: req.SourceFilePath,
-			TargetFile: req.TargetFilePath,
-			TargetHost: req.TargetHost,
-			StartTime: startTime,
-			EndTime:   time.Now(),
-			Duration:  time.Since(startTime).String(),
-			Error:     fmt.Sprintf("failed to create target file: %v", err),
-		}, err
+	var bytesWritten int64
+	if req.Chunked {
+		bytesWritten, err = c.transferFileChunked(ctx, conn, req, srcFile, totalBytes, onProgress)
+	} else {
+		bytesWritten, err = c.transferFileSingleStream(ctx, conn, req, srcFile, totalBytes, onProgress)
 	}
-	defer dstFile.Close()
-
-	// Copy file contents
-	bytesWritten, err := io.Copy(dstFile, srcFile)
 	if err != nil {
 		return &models.FileTransferResponse{
-			Status:    "failed",
-			SourceFile: req.SourceFilePath,
-			TargetFile: req.TargetFilePath,
-			TargetHost: req.TargetHost,
+			Status:       "failed",
+			SourceFile:   req.SourceFilePath,
+			TargetFile:   req.TargetFilePath,
+			TargetHost:   req.TargetHost,
 			BytesWritten: bytesWritten,
-			StartTime: startTime,
-			EndTime:   time.Now(),
-			Duration:  time.Since(startTime).String(),
-			Error:     fmt.Sprintf("failed to copy file contents: %v", err),
+			StartTime:    startTime,
+			EndTime:      time.Now(),
+			Duration:     time.Since(startTime).String(),
+			Error:        fmt.Sprintf("failed to transfer file: %v", err),
 		}, err
 	}
 
@@ -194,5 +277,101 @@ func (c *Client) TransferFile(req models.FileTransferRequest) (*models.FileTrans
 		StartTime:  startTime,
 		EndTime:    endTime,
 		Duration:   endTime.Sub(startTime).String(),
+		AuthMethod: *usedAuthMethod,
 	}, nil
 }
+
+// transferFileSingleStream copies srcFile to the remote path over a single
+// SFTP channel. It's the default mode and is bandwidth-limited by that
+// channel's per-request round trips; see transferFileChunked for large
+// files.
+func (c *Client) transferFileSingleStream(ctx context.Context, conn *ssh.Client, req models.FileTransferRequest, srcFile *os.File, totalBytes int64, onProgress func(written, total int64)) (int64, error) {
+	sftpClient, err := sftp.NewClient(conn)
+	if err != nil {
+		return 0, fmt.Errorf("unable to create SFTP client: %v", err)
+	}
+	defer sftpClient.Close()
+
+	targetDir := filepath.Dir(req.TargetFilePath)
+	if err := sftpClient.MkdirAll(targetDir); err != nil {
+		return 0, fmt.Errorf("unable to create target directory: %v", err)
+	}
+
+	dstFile, err := sftpClient.Create(req.TargetFilePath)
+	if err != nil {
+		return 0, fmt.Errorf("unable to create target file: %v", err)
+	}
+	defer dstFile.Close()
+
+	pr := &progressReader{r: srcFile, ctx: ctx}
+	stopProgress := make(chan struct{})
+	if onProgress != nil {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		go func() {
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					onProgress(atomic.LoadInt64(&pr.written), totalBytes)
+				case <-stopProgress:
+					onProgress(atomic.LoadInt64(&pr.written), totalBytes)
+					return
+				}
+			}
+		}()
+	}
+
+	bytesWritten, err := io.Copy(dstFile, pr)
+	close(stopProgress)
+	return bytesWritten, err
+}
+
+// dialContext dials addr and performs the SSH handshake, aborting early if
+// ctx is canceled first.
+func dialContext(ctx context.Context, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	netConn, err := (&net.Dialer{Timeout: config.Timeout}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		conn *ssh.Client
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, config)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		done <- result{conn: ssh.NewClient(sshConn, chans, reqs)}
+	}()
+
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-ctx.Done():
+		netConn.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// progressReader wraps an io.Reader, tracking how many bytes have been read
+// and aborting with ctx's error once it is canceled.
+type progressReader struct {
+	r       io.Reader
+	ctx     context.Context
+	written int64
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(buf)
+	if n > 0 {
+		atomic.AddInt64(&p.written, int64(n))
+	}
+	return n, err
+}
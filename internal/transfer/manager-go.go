@@ -0,0 +1,242 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yourusername/ssh-file-transfer-api/internal/models"
+)
+
+// State is the lifecycle state of a tracked transfer.
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateCompleted State = "completed"
+	StateFailed    State = "failed"
+	StateCanceled  State = "canceled"
+)
+
+// Record is the persisted view of a single transfer's progress.
+type Record struct {
+	ID               string    `json:"id"`
+	Status           State     `json:"status"`
+	BytesTransferred int64     `json:"bytes_transferred"`
+	TotalBytes       int64     `json:"total_bytes,omitempty"`
+	ThroughputBps    float64   `json:"throughput_bytes_per_sec"`
+	ETASeconds       float64   `json:"eta_seconds,omitempty"`
+	StartTime        time.Time `json:"start_time"`
+	LastUpdated      time.Time `json:"last_updated"`
+	EndTime          time.Time `json:"end_time,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	// AuthMethod records which authentication method the server accepted:
+	// "agent", "publickey", "password", or "keyboard-interactive".
+	AuthMethod string `json:"auth_method,omitempty"`
+	// Files holds the per-file outcome of a recursive (directory/glob)
+	// transfer, including a dry_run one; it is empty for a single-file
+	// transfer.
+	Files []models.FileResult `json:"files,omitempty"`
+}
+
+// ErrNotCancelable is returned by Cancel when the transfer has already
+// finished (or never existed).
+var ErrNotCancelable = errors.New("transfer is not running")
+
+// TransferFunc performs the actual copy for a single transfer. It must
+// honor ctx cancellation and report progress via onProgress as it goes.
+type TransferFunc func(ctx context.Context, req models.FileTransferRequest, onProgress func(written, total int64)) (*models.FileTransferResponse, error)
+
+// Manager assigns IDs to incoming transfer requests, runs them in the
+// background, and tracks their progress in a Store so status survives
+// polling, streaming, and (with a persistent Store) server restarts.
+type Manager struct {
+	store    Store
+	transfer TransferFunc
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	subs    map[string][]chan Record
+}
+
+// NewManager creates a Manager backed by store, using fn to perform each
+// transfer's actual copy.
+func NewManager(store Store, fn TransferFunc) *Manager {
+	return &Manager{
+		store:    store,
+		transfer: fn,
+		cancels:  make(map[string]context.CancelFunc),
+		subs:     make(map[string][]chan Record),
+	}
+}
+
+// Start assigns a new transfer ID, persists it as queued, and runs it in a
+// background goroutine. It returns immediately with the assigned ID.
+func (m *Manager) Start(req models.FileTransferRequest) (string, error) {
+	id := fmt.Sprintf("transfer-%d", time.Now().UnixNano())
+	now := time.Now()
+
+	rec := &Record{ID: id, Status: StateQueued, StartTime: now, LastUpdated: now}
+	if err := m.store.Save(rec); err != nil {
+		return "", fmt.Errorf("unable to persist transfer: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	m.cancels[id] = cancel
+	m.mu.Unlock()
+
+	go m.run(ctx, id, req)
+
+	return id, nil
+}
+
+// Get returns the current record for id.
+func (m *Manager) Get(id string) (*Record, error) {
+	return m.store.Get(id)
+}
+
+// Cancel requests that a queued or running transfer stop. It returns
+// ErrNotCancelable if the transfer has already reached a terminal state.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	cancel, ok := m.cancels[id]
+	m.mu.Unlock()
+	if !ok {
+		return ErrNotCancelable
+	}
+	cancel()
+	return nil
+}
+
+// Subscribe registers a channel that receives every Record update for id
+// from this point on, until the returned unsubscribe function is called,
+// and returns a snapshot of the current record so the caller can replay
+// the transfer's state as of the moment it subscribed (e.g. a transfer
+// that already finished before the caller subscribed). The channel is
+// buffered and updates are dropped (not blocked on) if the subscriber
+// falls behind.
+func (m *Manager) Subscribe(id string) (Record, <-chan Record, func()) {
+	ch := make(chan Record, 8)
+
+	m.mu.Lock()
+	m.subs[id] = append(m.subs[id], ch)
+	m.mu.Unlock()
+
+	// Fetched after registering the channel (not before), so an update
+	// racing with Subscribe is at worst delivered twice rather than
+	// missed entirely.
+	var current Record
+	if rec, err := m.store.Get(id); err == nil {
+		current = *rec
+	}
+
+	unsubscribe := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		subs := m.subs[id]
+		for i, c := range subs {
+			if c == ch {
+				m.subs[id] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}
+	return current, ch, unsubscribe
+}
+
+func (m *Manager) run(ctx context.Context, id string, req models.FileTransferRequest) {
+	m.update(id, func(r *Record) { r.Status = StateRunning })
+
+	var progressMu sync.Mutex
+	var lastBytes int64
+	lastTick := time.Now()
+
+	// Chunked, concurrent transfers call onProgress from multiple worker
+	// goroutines at once (see ssh.transferFileChunked); without this lock,
+	// lastBytes/lastTick below and the read-modify-write in update race
+	// across calls and lose updates.
+	onProgress := func(written, total int64) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+
+		now := time.Now()
+		elapsed := now.Sub(lastTick).Seconds()
+		throughput := 0.0
+		if elapsed > 0 {
+			throughput = float64(written-lastBytes) / elapsed
+		}
+		lastBytes = written
+		lastTick = now
+
+		m.update(id, func(r *Record) {
+			r.BytesTransferred = written
+			r.TotalBytes = total
+			r.ThroughputBps = throughput
+			r.LastUpdated = now
+			if throughput > 0 && total > written {
+				r.ETASeconds = float64(total-written) / throughput
+			} else {
+				r.ETASeconds = 0
+			}
+		})
+	}
+
+	resp, err := m.transfer(ctx, req, onProgress)
+
+	m.mu.Lock()
+	delete(m.cancels, id)
+	m.mu.Unlock()
+
+	m.update(id, func(r *Record) {
+		r.EndTime = time.Now()
+		r.LastUpdated = r.EndTime
+		r.ThroughputBps = 0
+		r.ETASeconds = 0
+
+		switch {
+		case errors.Is(ctx.Err(), context.Canceled):
+			r.Status = StateCanceled
+			r.Error = "transfer canceled"
+		case err != nil:
+			r.Status = StateFailed
+			r.Error = err.Error()
+		default:
+			r.Status = StateCompleted
+			if resp != nil {
+				r.BytesTransferred = resp.BytesWritten
+			}
+		}
+
+		if resp != nil {
+			r.AuthMethod = resp.AuthMethod
+			r.Files = resp.Files
+		}
+	})
+}
+
+func (m *Manager) update(id string, mutate func(*Record)) {
+	rec, err := m.store.Get(id)
+	if err != nil {
+		return
+	}
+	mutate(rec)
+	if err := m.store.Save(rec); err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	subs := append([]chan Record(nil), m.subs[id]...)
+	m.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- *rec:
+		default:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package transfer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/ssh-file-transfer-api/internal/models"
+)
+
+// TestManagerConcurrentOnProgress exercises onProgress being called from
+// multiple goroutines at once, the way a chunked+concurrent transfer does
+// (see ssh.transferFileChunked). Run with -race: before the progressMu fix in
+// Manager.run, this reliably reported a data race on the closure's
+// lastBytes/lastTick locals and on the Record read-modify-write in update.
+func TestManagerConcurrentOnProgress(t *testing.T) {
+	const workers = 8
+
+	fn := func(ctx context.Context, req models.FileTransferRequest, onProgress func(written, total int64)) (*models.FileTransferResponse, error) {
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				onProgress(int64(n+1)*1024, workers*1024)
+			}(i)
+		}
+		wg.Wait()
+		return &models.FileTransferResponse{BytesWritten: workers * 1024}, nil
+	}
+
+	m := NewManager(NewMemoryStore(), fn)
+	id, err := m.Start(models.FileTransferRequest{})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if rec.Status == StateCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("transfer did not complete in time, last status: %s", rec.Status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
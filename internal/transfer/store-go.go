@@ -0,0 +1,51 @@
+package transfer
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound indicates no transfer record exists for the given ID.
+var ErrNotFound = errors.New("transfer not found")
+
+// Store persists and retrieves transfer Records. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Save creates or overwrites the record for rec.ID.
+	Save(rec *Record) error
+	// Get returns the record for id, or ErrNotFound if it doesn't exist.
+	Get(id string) (*Record, error)
+}
+
+// MemoryStore is an in-memory Store. It is the default backend; records do
+// not survive a process restart.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Record)}
+}
+
+func (s *MemoryStore) Save(rec *Record) error {
+	cp := *rec
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[rec.ID] = &cp
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *rec
+	return &cp, nil
+}